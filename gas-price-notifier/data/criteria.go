@@ -0,0 +1,82 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Criteria - Structured filter evaluated against a `newGasPrice` update
+// before it's forwarded to the client, replacing the old `op`/`threshold`
+// pair that was never actually compared against anything
+type Criteria struct {
+	// Field - Which `GasPricePayload` field to compare: `fast`, `fastest`
+	// or `safeLow`
+	Field string `json:"field"`
+
+	// Op - Comparator: `>=`, `<=`, `==`, `!=` or `between`
+	Op string `json:"op"`
+
+	// Value - Right-hand side for every `Op` except `between`
+	Value float64 `json:"value,omitempty"`
+
+	// Values - `[low, high]` bounds, required for `Op == "between"`
+	Values []float64 `json:"values,omitempty"`
+}
+
+// Validate - Checking that `Field`/ `Op`/ `Value(s)` form a well-formed criteria
+func (c *Criteria) Validate() error {
+
+	switch c.Field {
+	case "fast", "fastest", "safeLow":
+	default:
+		return fmt.Errorf("invalid `criteria.field` : %s", c.Field)
+	}
+
+	switch c.Op {
+
+	case ">=", "<=", "==", "!=":
+		return nil
+
+	case "between":
+		if len(c.Values) != 2 {
+			return errors.New("`criteria.values` must have exactly 2 elements for `between`")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("invalid `criteria.op` : %s", c.Op)
+
+	}
+
+}
+
+// Evaluate - Checking whether `price` satisfies this criteria
+func (c *Criteria) Evaluate(price *GasPricePayload) bool {
+
+	var field float64
+
+	switch c.Field {
+	case "fast":
+		field = price.Fast
+	case "fastest":
+		field = price.Fastest
+	case "safeLow":
+		field = price.SafeLow
+	}
+
+	switch c.Op {
+	case ">=":
+		return field >= c.Value
+	case "<=":
+		return field <= c.Value
+	case "==":
+		return field == c.Value
+	case "!=":
+		return field != c.Value
+	case "between":
+		return field >= c.Values[0] && field <= c.Values[1]
+	default:
+		return false
+	}
+
+}
@@ -0,0 +1,90 @@
+package data
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCriteriaValidate(t *testing.T) {
+
+	cases := []struct {
+		name    string
+		c       Criteria
+		wantErr bool
+	}{
+		{"valid >=", Criteria{Field: "fast", Op: ">=", Value: 10}, false},
+		{"valid <=", Criteria{Field: "fastest", Op: "<=", Value: 10}, false},
+		{"valid ==", Criteria{Field: "safeLow", Op: "==", Value: 10}, false},
+		{"valid !=", Criteria{Field: "fast", Op: "!=", Value: 10}, false},
+		{"valid between", Criteria{Field: "fast", Op: "between", Values: []float64{1, 2}}, false},
+		{"between missing values", Criteria{Field: "fast", Op: "between"}, true},
+		{"between too many values", Criteria{Field: "fast", Op: "between", Values: []float64{1, 2, 3}}, true},
+		{"bad field", Criteria{Field: "bogus", Op: ">="}, true},
+		{"bad op", Criteria{Field: "fast", Op: "~="}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.c.Validate()
+			if (err != nil) != c.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, c.wantErr)
+			}
+		})
+	}
+
+}
+
+func TestCriteriaEvaluate(t *testing.T) {
+
+	price := &GasPricePayload{Token: "eth", Fast: 50, Fastest: 80, SafeLow: 20}
+
+	cases := []struct {
+		name string
+		c    Criteria
+		want bool
+	}{
+		{">= matches", Criteria{Field: "fast", Op: ">=", Value: 50}, true},
+		{">= fails", Criteria{Field: "fast", Op: ">=", Value: 51}, false},
+		{"<= matches", Criteria{Field: "safeLow", Op: "<=", Value: 20}, true},
+		{"<= fails", Criteria{Field: "safeLow", Op: "<=", Value: 19}, false},
+		{"== matches", Criteria{Field: "fastest", Op: "==", Value: 80}, true},
+		{"== fails", Criteria{Field: "fastest", Op: "==", Value: 79}, false},
+		{"!= matches", Criteria{Field: "fastest", Op: "!=", Value: 79}, true},
+		{"!= fails", Criteria{Field: "fastest", Op: "!=", Value: 80}, false},
+		{"between matches", Criteria{Field: "fast", Op: "between", Values: []float64{40, 60}}, true},
+		{"between fails below", Criteria{Field: "fast", Op: "between", Values: []float64{51, 60}}, false},
+		{"between fails above", Criteria{Field: "fast", Op: "between", Values: []float64{10, 20}}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.c.Evaluate(price); got != c.want {
+				t.Fatalf("Evaluate() = %v, want %v", got, c.want)
+			}
+		})
+	}
+
+}
+
+func BenchmarkCriteriaEvaluate(b *testing.B) {
+
+	price := &GasPricePayload{Token: "eth", Fast: 50, Fastest: 80, SafeLow: 20}
+	criteria := &Criteria{Field: "fast", Op: "between", Values: []float64{40, 60}}
+
+	b.Run("evaluate", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			criteria.Evaluate(price)
+		}
+	})
+
+	b.Run("jsonEncode", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := json.Marshal(price); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+}
@@ -0,0 +1,56 @@
+package data
+
+import "encoding/json"
+
+// PubSubPayload - Typed envelope published on the pub/sub channel by the
+// upstream producer. `Data` is decoded into `GasPricePayload`/
+// `BlockPayload`/ `LogPayload`, depending on `Kind`, once a subscriber's
+// `Kind` has been matched against it
+type PubSubPayload struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// GasPricePayload - Decoded `gasPrice` event data, published by the
+// upstream price producer
+type GasPricePayload struct {
+	Token   string  `json:"token"`
+	Fast    float64 `json:"fast"`
+	Fastest float64 `json:"fastest"`
+	SafeLow float64 `json:"safeLow"`
+
+	// Channel - Redis channel this update was actually published to,
+	// filled in only for pattern-based subscriptions so a client
+	// watching e.g. `gas.*` can tell which token/network an update
+	// originated from
+	Channel string `json:"channel,omitempty"`
+}
+
+// BlockPayload - Decoded `block` event data, published whenever the
+// upstream producer observes a new block
+type BlockPayload struct {
+	Number    uint64 `json:"number"`
+	Hash      string `json:"hash"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// LogPayload - Decoded `log` event data, published whenever the
+// upstream producer observes a log event
+type LogPayload struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+// producerKindFor - Mapping a subscription's `Kind` onto the envelope
+// `kind` the upstream producer tags matching events with
+func producerKindFor(subscriptionKind string) string {
+	switch subscriptionKind {
+	case KindNewBlock:
+		return "block"
+	case KindLogs:
+		return "log"
+	default:
+		return "gasPrice"
+	}
+}
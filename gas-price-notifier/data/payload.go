@@ -0,0 +1,132 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Subscription kinds gasz supports, named after their go-ethereum
+// `eth_subscribe` counterparts
+const (
+	KindNewGasPrice = "newGasPrice"
+	KindNewBlock    = "newBlock"
+	KindLogs        = "logs"
+)
+
+// LogsFilter - Filter criteria for a `logs` subscription; at least one
+// of `Address`/ `Topics` must be set
+type LogsFilter struct {
+	Address string   `json:"address,omitempty"`
+	Topics  []string `json:"topics,omitempty"`
+}
+
+// Payload - Structure of subscription/ unsubscription request, received
+// from client connected over websocket
+type Payload struct {
+	Type string `json:"type"`
+
+	// Kind - Which event stream this subscription wants: `newGasPrice`
+	// (the default, when empty), `newBlock` or `logs`
+	Kind string `json:"kind,omitempty"`
+
+	// Token - Which price feed a `newGasPrice` subscription cares about
+	Token string `json:"token"`
+
+	// Criteria - Filter evaluated against each `newGasPrice` update before
+	// it's forwarded to the client
+	Criteria *Criteria `json:"criteria,omitempty"`
+
+	// Op/ Threshold - Deprecated, pre-`Criteria` shape of a `newGasPrice`
+	// filter, kept for the legacy `v1/subscribe` envelope; `Validate`
+	// translates them into an equivalent `Criteria` (against `fast`, the
+	// field this pair always implicitly meant) when `Criteria` itself is
+	// left unset
+	Op        string  `json:"op,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+
+	// LogsFilter - Filter criteria for a `logs` subscription
+	LogsFilter *LogsFilter `json:"logsFilter,omitempty"`
+
+	// Channel - Redis channel (or, when it contains glob characters such
+	// as `*`/`?`/`[...]`, a pattern) to subscribe to in place of the
+	// default configured via `RedisPubSubChannel`
+	Channel string `json:"channel,omitempty"`
+
+	// Replay - When > 0, number of seconds of history to replay from the
+	// broker (when it supports it, i.e. the NATS JetStream backend)
+	// before live updates start; ignored by the Redis backend
+	Replay int64 `json:"replay,omitempty"`
+
+	// MinInterval - When > 0, minimum number of milliseconds to let elapse
+	// between two updates forwarded to the client, so a value flapping
+	// around a threshold doesn't spam it
+	MinInterval int64 `json:"minInterval,omitempty"`
+
+	// ID - Server-issued JSON-RPC subscription id, set only when this
+	// `Payload` originated from the `gasz_subscribe` method instead of
+	// the legacy `v1/subscribe` envelope
+	ID string `json:"-"`
+}
+
+// String - Stringified representation of payload, to be used as
+// unique key for keeping track of this subscription
+func (p *Payload) String() string {
+	return fmt.Sprintf("%s_%s_%s_%+v", p.Kind, p.Channel, p.Token, p.Criteria)
+}
+
+// Validate - Checking whether received payload is good enough to be
+// processed further or not
+func (p *Payload) Validate() error {
+
+	switch p.Type {
+	case "subscription", "unsubscription":
+	default:
+		return fmt.Errorf("invalid `type` : %s", p.Type)
+	}
+
+	switch p.Kind {
+
+	case "", KindNewGasPrice:
+
+		if p.Token == "" {
+			return errors.New("missing `token`")
+		}
+
+		if p.Criteria == nil && p.Op != "" {
+			p.Criteria = &Criteria{Field: "fast", Op: p.Op, Value: p.Threshold}
+		}
+
+		if p.Criteria == nil {
+			return errors.New("missing `criteria`")
+		}
+
+		if err := p.Criteria.Validate(); err != nil {
+			return err
+		}
+
+	case KindNewBlock:
+		// No filter criteria to validate - every new block is forwarded
+
+	case KindLogs:
+		if p.LogsFilter == nil || (p.LogsFilter.Address == "" && len(p.LogsFilter.Topics) == 0) {
+			return errors.New("missing `logsFilter`")
+		}
+
+	default:
+		return fmt.Errorf("invalid `kind` : %s", p.Kind)
+	}
+
+	return nil
+
+}
+
+// ClientResponse - Generic acknowledgement/ error frame sent back to
+// client over websocket connection, for the legacy `v1/subscribe` protocol
+type ClientResponse struct {
+	Code    uint   `json:"code"`
+	Message string `json:"message"`
+
+	// Pattern - Set on subscribe confirmations to report whether the
+	// match was pattern-based (`PSUBSCRIBE`) rather than an exact channel
+	Pattern bool `json:"pattern,omitempty"`
+}
@@ -0,0 +1,31 @@
+package data
+
+import "testing"
+
+func TestPayloadValidateLegacyOpThreshold(t *testing.T) {
+
+	p := Payload{Type: "subscription", Token: "ETH", Op: ">=", Threshold: 50}
+
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Validate() on a legacy op/threshold payload returned error : %s", err)
+	}
+
+	if p.Criteria == nil {
+		t.Fatal("Validate() did not translate `op`/`threshold` into `Criteria`")
+	}
+
+	if p.Criteria.Field != "fast" || p.Criteria.Op != ">=" || p.Criteria.Value != 50 {
+		t.Fatalf("translated Criteria = %+v, want {Field:fast Op:>= Value:50}", p.Criteria)
+	}
+
+}
+
+func TestPayloadValidateMissingCriteria(t *testing.T) {
+
+	p := Payload{Type: "subscription", Token: "ETH"}
+
+	if err := p.Validate(); err == nil {
+		t.Fatal("Validate() should reject a newGasPrice payload with neither `criteria` nor legacy `op`/`threshold`")
+	}
+
+}
@@ -0,0 +1,37 @@
+package data
+
+import "time"
+
+// rateLimiter - Drops updates that arrive less than `interval` after the
+// last one let through, so a value flapping around a subscriber's
+// threshold doesn't spam the client. Not safe for concurrent use on its
+// own - each `PriceSubscription` owns its own, and calls `Allow` from
+// both its `Listen` goroutine and its `params.replay` goroutine, so the
+// caller must serialize those calls itself (via `PriceSubscription.Lock`)
+type rateLimiter struct {
+	interval time.Duration
+	last     time.Time
+}
+
+// newRateLimiter - Building a rate limiter; `interval <= 0` disables
+// limiting, `Allow` then always returns true
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{interval: interval}
+}
+
+// Allow - Checking whether an update arriving at `now` should be let
+// through, recording it as the last delivery when it is
+func (r *rateLimiter) Allow(now time.Time) bool {
+
+	if r.interval <= 0 {
+		return true
+	}
+
+	if !r.last.IsZero() && now.Sub(r.last) < r.interval {
+		return false
+	}
+
+	r.last = now
+	return true
+
+}
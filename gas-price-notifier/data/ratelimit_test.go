@@ -0,0 +1,40 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterDisabled(t *testing.T) {
+
+	r := newRateLimiter(0)
+	now := time.Now()
+
+	if !r.Allow(now) || !r.Allow(now) {
+		t.Fatal("a zero interval should never reject an update")
+	}
+
+}
+
+func TestRateLimiterThrottles(t *testing.T) {
+
+	r := newRateLimiter(time.Second)
+	now := time.Now()
+
+	if !r.Allow(now) {
+		t.Fatal("first update should always be let through")
+	}
+
+	if r.Allow(now.Add(500 * time.Millisecond)) {
+		t.Fatal("update arriving before `interval` has elapsed should be rejected")
+	}
+
+	if !r.Allow(now.Add(time.Second)) {
+		t.Fatal("update arriving exactly at `interval` should be let through")
+	}
+
+	if !r.Allow(now.Add(2 * time.Second)) {
+		t.Fatal("update arriving well after `interval` should be let through")
+	}
+
+}
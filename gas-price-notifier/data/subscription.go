@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"gas-price-notifier/config"
+	"gas-price-notifier/jsonrpc"
+	"gas-price-notifier/pubsub"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/websocket"
 )
 
@@ -16,98 +19,334 @@ import (
 // for each client
 //
 // Functions defined on this struct, are supposed to be invoked for subscribing to and unsubscribing from
-// Redis pubsub topic, where price feed data is being published
+// the shared pub/sub hub, where price feed data gets fanned out from a single upstream broker subscription
 type PriceSubscription struct {
-	Client  *websocket.Conn
-	Request *Payload
-	Redis   *redis.Client
-	PubSub  *redis.PubSub
+	Client     *websocket.Conn
+	Request    *Payload
+	Hub        *pubsub.Hub
+	Lock       *sync.Mutex
+	messages   <-chan pubsub.Message
+	cancel     pubsub.CancelFunc
+	pattern    bool
+	channel    string
+	limiter    *rateLimiter
+	done       chan struct{}
+	stopOnce   sync.Once
+	replayDone chan struct{}
 }
 
-// Subscribe - Subscribing to Redis pubsub channel
-// so that any time new price feed is posted in channel
-// listener will get notified & take proper measurements
-// if conditions satisfy
-func (ps *PriceSubscription) Subscribe(ctx context.Context) {
-	ps.PubSub = ps.Redis.Subscribe(ctx, config.Get("RedisPubSubChannel"))
+// isGlobPattern - Checking whether `channel` contains any glob
+// characters, in which case it's treated as a pattern instead of an
+// exact channel name
+func isGlobPattern(channel string) bool {
+	return strings.ContainsAny(channel, "*?[")
 }
 
-// Listen - Subscribing to Redis pubsub and waiting for message
-// to be published, as soon as it's published it's being sent to
-// client application, connected via websocket connection
-//
+// Subscribe - Registering interest in the requested channel (or
+// pattern, when it contains glob characters) against the shared hub,
+// instead of opening an independent broker subscriber per client
 //
+// Returns an error if the underlying broker subscription fails - the
+// caller must not proceed to run `Listen` in that case
+func (ps *PriceSubscription) Subscribe(ctx context.Context) error {
+
+	ps.channel = ps.Request.Channel
+	if ps.channel == "" {
+		ps.channel = config.Get("RedisPubSubChannel")
+	}
+
+	ps.pattern = isGlobPattern(ps.channel)
+	ps.limiter = newRateLimiter(time.Duration(ps.Request.MinInterval) * time.Millisecond)
+
+	var err error
+	if ps.pattern {
+		ps.messages, ps.cancel, err = ps.Hub.RegisterPattern(ctx, ps.channel)
+	} else {
+		ps.messages, ps.cancel, err = ps.Hub.Register(ctx, ps.channel)
+	}
+
+	if err != nil {
+
+		// JSON-RPC subscriptions report this back as a proper JSON-RPC
+		// error from `registerGaszNamespace` instead of a `ClientResponse`
+		if ps.Request.ID == "" {
+
+			resp := ClientResponse{
+				Code:    0,
+				Message: fmt.Sprintf("Failed to subscribe to `%s` : %s", ps.Request, err.Error()),
+			}
+
+			ps.Lock.Lock()
+			writeErr := ps.Client.WriteJSON(&resp)
+			ps.Lock.Unlock()
+
+			if writeErr != nil {
+				log.Printf("[!] Failed to communicate with client : %s\n", writeErr.Error())
+			}
+
+		}
+
+		return err
+
+	}
+
+	// JSON-RPC subscriptions already got their acknowledgement in the form
+	// of the subscription ID returned from `gasz_subscribe` itself, so no
+	// extra confirmation frame is sent here
+	if ps.Request.ID == "" {
+
+		resp := ClientResponse{
+			Code:    1,
+			Message: fmt.Sprintf("Subscribed to `%s`", ps.Request),
+			Pattern: ps.pattern,
+		}
+
+		ps.Lock.Lock()
+		err := ps.Client.WriteJSON(&resp)
+		ps.Lock.Unlock()
+
+		if err != nil {
+			log.Printf("[!] Failed to communicate with client : %s\n", err.Error())
+		}
+
+	}
+
+	// `params.replay` asks the broker (when it supports it, i.e. NATS
+	// JetStream) to deliver recent history before live updates start. Runs
+	// in its own goroutine so replaying - which can take up to several
+	// seconds - doesn't block the connection's read loop or delay the
+	// subscribe acknowledgement above. `Listen` gates live delivery on
+	// `replayDone` so history still arrives before anything live, even
+	// though the two run concurrently here; live messages queue in the
+	// Hub's 16-slot listener channel until replay finishes, and the Hub
+	// drops any that arrive while that channel is full
+	if ps.Request.Replay > 0 {
+		ps.replayDone = make(chan struct{})
+		go func() {
+			defer close(ps.replayDone)
+			since := time.Duration(ps.Request.Replay) * time.Second
+			deliver := func(msg pubsub.Message) { ps.handleMessage(msg) }
+			if err := ps.Hub.Replay(ctx, ps.channel, since, deliver); err != nil {
+				log.Printf("[!] Failed to replay `%s` : %s\n", ps.channel, err.Error())
+			}
+		}()
+	}
+
+	return nil
+
+}
+
+// Stop - Signalling that this subscription should be torn down,
+// for both the legacy handler and `gasz_unsubscribe` to call. `Listen`
+// never reads `Request.Type`; closing `done` is what ends it, so it
+// returns promptly even on a channel that produces no further messages
+func (ps *PriceSubscription) Stop() {
+	ps.Request.Type = "unsubscription"
+	ps.stopOnce.Do(func() { close(ps.done) })
+}
+
+// Listen - Waiting for messages fanned out by the shared pub/sub hub and,
+// as soon as one arrives, forwarding it to the client application,
+// connected over websocket connection
 func (ps *PriceSubscription) Listen(ctx context.Context) {
 
 	// Scheduling unsubscription call here, to be invoked when
 	// returning from this function
 	defer ps.Unsubscribe(ctx)
 
+	// Holding off on live delivery until `params.replay` (if requested)
+	// has finished, so a reconnecting client always sees its history
+	// before anything live - messages that arrive in the meantime sit in
+	// the Hub's 16-slot listener channel until then, and any beyond that
+	// are dropped by the Hub
+	if ps.replayDone != nil {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ps.done:
+			return
+		case <-ps.replayDone:
+		}
+	}
+
 	for {
 
-		if ps.Request.Type != "subscription" {
-			break
+		select {
+
+		case <-ctx.Done():
+			return
+
+		case <-ps.done:
+			// Explicit unsubscribe via `Stop`, even on a channel that
+			// never produces another message
+			return
+
+		case msg, ok := <-ps.messages:
+
+			if !ok {
+				// Hub tore this listener down, nothing more to read
+				return
+			}
+
+			if !ps.handleMessage(msg) {
+				return
+			}
+
 		}
 
-		msg, err := ps.PubSub.ReceiveTimeout(ctx, time.Second)
-		if err != nil {
-			continue
+	}
+
+}
+
+// handleMessage - Decoding one typed envelope, matching it against this
+// subscription's `Kind` and filter criteria, and delivering it to the
+// client when it matches
+//
+// Shared between live delivery in `Listen` and historical delivery in
+// `Subscribe`'s `params.replay` handling. Returns false if the client
+// connection should be torn down
+func (ps *PriceSubscription) handleMessage(msg pubsub.Message) bool {
+
+	var envelope PubSubPayload
+	if err := json.Unmarshal(msg.Data, &envelope); err != nil {
+		log.Printf("[!] Failed to decode received data from pubsub channel : %s\n", err.Error())
+		return true
+	}
+
+	if envelope.Kind != producerKindFor(ps.Request.Kind) {
+		// Not the event type this subscription cares about
+		return true
+	}
+
+	result, matched, err := ps.match(envelope.Data)
+	if err != nil {
+		log.Printf("[!] Failed to decode `%s` event : %s\n", envelope.Kind, err.Error())
+		return true
+	}
+
+	if !matched {
+		return true
+	}
+
+	// Guarded by `ps.Lock` - `handleMessage` can run concurrently from
+	// both the live `Listen` goroutine and an in-flight `params.replay`
+	// goroutine, and `limiter` isn't safe for concurrent use on its own
+	ps.Lock.Lock()
+	allowed := ps.limiter.Allow(time.Now())
+	ps.Lock.Unlock()
+
+	if !allowed {
+		// Matched, but arrived too soon after the last delivered update
+		return true
+	}
+
+	// Tagging the update with the channel it was actually published to,
+	// so a pattern subscriber can tell updates from different channels apart
+	if ps.pattern {
+		if gasPrice, ok := result.(*GasPricePayload); ok {
+			gasPrice.Channel = msg.Channel
 		}
+	}
 
-		var facedErrorInSwitchCase bool
+	// JSON-RPC subscriptions get wrapped in a `gasz_subscription`
+	// notification frame, keyed on the server-issued ID; legacy
+	// subscriptions keep receiving the bare payload they always have
+	var out interface{} = result
+	if ps.Request.ID != "" {
+		out = jsonrpc.NewNotification("gasz_subscription", ps.Request.ID, result)
+	}
 
-		switch m := msg.(type) {
+	ps.Lock.Lock()
+	err = ps.Client.WriteJSON(out)
+	ps.Lock.Unlock()
 
-		case *redis.Subscription:
+	if err != nil {
+		log.Printf("[!] Failed to communicate with client : %s\n", err.Error())
+		return false
+	}
 
-			resp := ClientResponse{
-				Code:    1,
-				Message: fmt.Sprintf("Subscribed to `%s`", ps.Request),
-			}
+	return true
 
-			if err := ps.Client.WriteJSON(&resp); err != nil {
-				facedErrorInSwitchCase = true
-				log.Printf("[!] Failed to communicate with client : %s\n", err.Error())
+}
 
-				break
-			}
+// match - Decoding `data` into the concrete type for this subscription's
+// `Kind` and checking it against the subscription's own filter criteria
+func (ps *PriceSubscription) match(data json.RawMessage) (interface{}, bool, error) {
 
-		case *redis.Message:
+	switch ps.Request.Kind {
 
-			var pubsubPayload PubSubPayload
-			_msg := []byte(m.Payload)
+	case KindNewBlock:
 
-			if err := json.Unmarshal(_msg, &pubsubPayload); err != nil {
-				facedErrorInSwitchCase = true
-				log.Printf("[!] Failed to decode received data from pubsub channel : %s\n", err.Error())
+		var block BlockPayload
+		if err := json.Unmarshal(data, &block); err != nil {
+			return nil, false, err
+		}
+
+		return &block, true, nil
+
+	case KindLogs:
+
+		var logPayload LogPayload
+		if err := json.Unmarshal(data, &logPayload); err != nil {
+			return nil, false, err
+		}
+
+		if filter := ps.Request.LogsFilter; filter != nil {
 
-				break
+			if filter.Address != "" && !strings.EqualFold(filter.Address, logPayload.Address) {
+				return nil, false, nil
 			}
 
-			if err := ps.Client.WriteJSON(&pubsubPayload); err != nil {
-				facedErrorInSwitchCase = true
-				log.Printf("[!] Failed to communicate with client : %s\n", err.Error())
+			if len(filter.Topics) > 0 && !topicsMatch(filter.Topics, logPayload.Topics) {
+				return nil, false, nil
 			}
 
 		}
 
-		// Checking whether we've encountered any error with in switch case
-		//
-		// If yes, we can break out of this loop
-		if facedErrorInSwitchCase {
-			break
+		return &logPayload, true, nil
+
+	default:
+
+		var gasPrice GasPricePayload
+		if err := json.Unmarshal(data, &gasPrice); err != nil {
+			return nil, false, err
+		}
+
+		if ps.Request.Token != "" && gasPrice.Token != ps.Request.Token {
+			return nil, false, nil
+		}
+
+		if criteria := ps.Request.Criteria; criteria != nil && !criteria.Evaluate(&gasPrice) {
+			return nil, false, nil
 		}
 
+		return &gasPrice, true, nil
+
 	}
 
 }
 
+// topicsMatch - Checking whether any of `got` appears among `want`
+func topicsMatch(want []string, got []string) bool {
+	for _, w := range want {
+		for _, g := range got {
+			if strings.EqualFold(w, g) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Unsubscribe - Cancelling price feed subscription for specific user
 // and letting client know about it
 func (ps *PriceSubscription) Unsubscribe(ctx context.Context) {
 
-	if err := ps.PubSub.Unsubscribe(ctx, config.Get("RedisPubSubChannel")); err != nil {
-		log.Printf("[!] Failed to unsubscribe from pubsub topic : %s\n", err.Error())
+	ps.cancel()
+
+	// JSON-RPC unsubscription is acknowledged by the boolean result of
+	// `gasz_unsubscribe` itself, so no extra confirmation frame is sent here
+	if ps.Request.ID != "" {
 		return
 	}
 
@@ -116,7 +355,11 @@ func (ps *PriceSubscription) Unsubscribe(ctx context.Context) {
 		Message: fmt.Sprintf("Unsubscribed from `%s`", ps.Request),
 	}
 
-	if err := ps.Client.WriteJSON(&resp); err != nil {
+	ps.Lock.Lock()
+	err := ps.Client.WriteJSON(&resp)
+	ps.Lock.Unlock()
+
+	if err != nil {
 		log.Printf("[!] Failed to communicate with client : %s\n", err.Error())
 	}
 
@@ -127,20 +370,29 @@ func (ps *PriceSubscription) Unsubscribe(ctx context.Context) {
 //
 // Whether client will receive notification that depends on whether received price value
 // satisfies criteria set by client
-func NewPriceSubscription(ctx context.Context, client *websocket.Conn, request *Payload, redisClient *redis.Client) *PriceSubscription {
+//
+// Returns an error, with a nil `*PriceSubscription`, if the underlying
+// broker subscription fails - the caller must not treat this client as
+// subscribed in that case
+func NewPriceSubscription(ctx context.Context, client *websocket.Conn, request *Payload, hub *pubsub.Hub, lock *sync.Mutex) (*PriceSubscription, error) {
 
 	ps := PriceSubscription{
 		Client:  client,
 		Request: request,
-		Redis:   redisClient,
+		Hub:     hub,
+		Lock:    lock,
+		done:    make(chan struct{}),
 	}
 
 	// Subscription object to be stored in 👆 struct
 	// after calling this function
-	ps.Subscribe(ctx)
+	if err := ps.Subscribe(ctx); err != nil {
+		return nil, err
+	}
+
 	// Running listener i.e. subscriber in different execution thread
 	go ps.Listen(ctx)
 
-	return &ps
+	return &ps, nil
 
-}
\ No newline at end of file
+}
@@ -0,0 +1,182 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gas-price-notifier/pubsub"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeBroker - Minimal `pubsub.Broker` standing in for Redis/ NATS,
+// letting a test publish synthetic messages without a live backend
+type fakeBroker struct {
+	messages chan pubsub.Message
+}
+
+func (f *fakeBroker) Publish(ctx context.Context, channel string, payload []byte) error {
+	return nil
+}
+
+func (f *fakeBroker) Subscribe(ctx context.Context, channel string, pattern bool) (<-chan pubsub.Message, pubsub.CancelFunc, error) {
+	return f.messages, func() {}, nil
+}
+
+// TestPriceSubscriptionPatternChannelTagging - Publishing to several
+// channels matching a `gas.*` pattern subscription and asserting the
+// client receives each update tagged with the channel it actually came
+// from, per chunk0-3
+func TestPriceSubscriptionPatternChannelTagging(t *testing.T) {
+
+	broker := &fakeBroker{messages: make(chan pubsub.Message, 4)}
+	hub := pubsub.NewHub(broker)
+
+	upgrader := websocket.Upgrader{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("failed to upgrade : %s", err.Error())
+			return
+		}
+		defer conn.Close()
+
+		payload := &Payload{Type: "subscription", Channel: "gas.*"}
+		if _, err := NewPriceSubscription(r.Context(), conn, payload, hub, &sync.Mutex{}); err != nil {
+			t.Errorf("failed to subscribe : %s", err.Error())
+			return
+		}
+
+		<-r.Context().Done()
+
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("failed to dial : %s", err.Error())
+	}
+	defer client.Close()
+
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	// Draining the subscribe acknowledgement sent before any update
+	var ack ClientResponse
+	if err := client.ReadJSON(&ack); err != nil {
+		t.Fatalf("failed to read subscribe ack : %s", err.Error())
+	}
+
+	publish := func(channel string, fast float64) {
+
+		gasPrice, _ := json.Marshal(GasPricePayload{Token: "ETH", Fast: fast})
+		envelope, _ := json.Marshal(PubSubPayload{Kind: "gasPrice", Data: gasPrice})
+
+		broker.messages <- pubsub.Message{Channel: channel, Data: envelope}
+
+	}
+
+	publish("gas.eth", 10)
+	publish("gas.btc", 20)
+
+	want := []struct {
+		channel string
+		fast    float64
+	}{
+		{"gas.eth", 10},
+		{"gas.btc", 20},
+	}
+
+	for _, w := range want {
+
+		client.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+		var got GasPricePayload
+		if err := client.ReadJSON(&got); err != nil {
+			t.Fatalf("failed to read update : %s", err.Error())
+		}
+
+		if got.Channel != w.channel {
+			t.Errorf("Channel = %q, want %q", got.Channel, w.channel)
+		}
+
+		if got.Fast != w.fast {
+			t.Errorf("Fast = %v, want %v", got.Fast, w.fast)
+		}
+
+	}
+
+}
+
+// TestPriceSubscriptionStopOnQuietChannel - `Stop` must tear a
+// subscription down even when its channel never produces another
+// message for `Listen` to wake up on, per chunk0-1
+func TestPriceSubscriptionStopOnQuietChannel(t *testing.T) {
+
+	broker := &fakeBroker{messages: make(chan pubsub.Message, 4)}
+	hub := pubsub.NewHub(broker)
+
+	upgrader := websocket.Upgrader{}
+	subscribed := make(chan *PriceSubscription, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("failed to upgrade : %s", err.Error())
+			return
+		}
+		defer conn.Close()
+
+		payload := &Payload{Type: "subscription", Channel: "gas.quiet"}
+		ps, err := NewPriceSubscription(r.Context(), conn, payload, hub, &sync.Mutex{})
+		if err != nil {
+			t.Errorf("failed to subscribe : %s", err.Error())
+			return
+		}
+		subscribed <- ps
+
+		<-r.Context().Done()
+
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	client, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("failed to dial : %s", err.Error())
+	}
+	defer client.Close()
+
+	client.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	// Draining the subscribe acknowledgement
+	var ack ClientResponse
+	if err := client.ReadJSON(&ack); err != nil {
+		t.Fatalf("failed to read subscribe ack : %s", err.Error())
+	}
+
+	ps := <-subscribed
+	ps.Stop()
+
+	// `gas.quiet` never publishes another message - without `done`
+	// wired into `Listen`'s select, this would hang until the 1s
+	// deadline fires instead of observing the unsubscribe ack promptly
+	client.SetReadDeadline(time.Now().Add(1 * time.Second))
+
+	var unsub ClientResponse
+	if err := client.ReadJSON(&unsub); err != nil {
+		t.Fatalf("Listen did not tear down after Stop() on a quiet channel : %s", err.Error())
+	}
+
+}
@@ -0,0 +1,80 @@
+// Package jsonrpc defines the JSON-RPC 2.0 envelope types gasz speaks
+// over its websocket subscription API, modeled on the shapes
+// go-ethereum uses for `eth_subscribe` / `eth_unsubscribe`
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+// Version - only JSON-RPC version this server speaks
+const Version = "2.0"
+
+// Well-known JSON-RPC 2.0 error codes this server returns
+const (
+	ErrCodeParse          = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeInternal       = -32603
+)
+
+// Request - Incoming JSON-RPC 2.0 call, as received from a client
+// connected over websocket
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      interface{}     `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Error - JSON-RPC 2.0 error object
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Response - Reply sent back for a `Request`
+type Response struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      interface{} `json:"id,omitempty"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   *Error      `json:"error,omitempty"`
+}
+
+// SubscriptionParams - Payload nested inside a `Notification` pushed for
+// an active subscription
+type SubscriptionParams struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+// Notification - Unsolicited push sent to a client for an active
+// subscription, modeled on go-ethereum's `eth_subscription`
+type Notification struct {
+	JSONRPC string             `json:"jsonrpc"`
+	Method  string             `json:"method"`
+	Params  SubscriptionParams `json:"params"`
+}
+
+// NewNotification - Building notification frame pushing `result` for
+// the subscription identified by `id`, under `method` (e.g. `gasz_subscription`)
+func NewNotification(method, id string, result interface{}) *Notification {
+	return &Notification{
+		JSONRPC: Version,
+		Method:  method,
+		Params: SubscriptionParams{
+			Subscription: id,
+			Result:       result,
+		},
+	}
+}
+
+var subscriptionSeq uint64
+
+// NextSubscriptionID - Generating next server-issued subscription ID
+func NextSubscriptionID() string {
+	return fmt.Sprintf("0x%x", atomic.AddUint64(&subscriptionSeq, 1))
+}
@@ -0,0 +1,87 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+
+	"gas-price-notifier/jsonrpc"
+)
+
+// HandlerFunc - Handles one JSON-RPC method call, returning either a
+// result to embed in the response or an error
+type HandlerFunc func(params []byte) (interface{}, *jsonrpc.Error)
+
+// Dispatcher - Routes incoming JSON-RPC 2.0 requests to a registered
+// handler by namespace prefix (the part of the method name before the
+// first underscore), so that adding a future namespace such as `admin_`
+// or `stats_` is just another `Register` call away
+type Dispatcher struct {
+	namespaces map[string]map[string]HandlerFunc
+}
+
+// NewDispatcher - Creating empty JSON-RPC method dispatcher
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{namespaces: make(map[string]map[string]HandlerFunc)}
+}
+
+// Register - Registering `handler` to serve `namespace_method` calls
+func (d *Dispatcher) Register(namespace string, method string, handler HandlerFunc) {
+
+	if d.namespaces[namespace] == nil {
+		d.namespaces[namespace] = make(map[string]HandlerFunc)
+	}
+
+	d.namespaces[namespace][method] = handler
+
+}
+
+// Dispatch - Routing `req` to its registered handler and building the
+// JSON-RPC 2.0 response, filling in a method-not-found/ invalid-params
+// error where applicable
+func (d *Dispatcher) Dispatch(req *jsonrpc.Request) *jsonrpc.Response {
+
+	resp := &jsonrpc.Response{JSONRPC: jsonrpc.Version, ID: req.ID}
+
+	namespace, method, ok := splitMethod(req.Method)
+	if !ok {
+		resp.Error = &jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeMethodNotFound,
+			Message: fmt.Sprintf("method `%s` not found", req.Method),
+		}
+
+		return resp
+	}
+
+	handler, ok := d.namespaces[namespace][method]
+	if !ok {
+		resp.Error = &jsonrpc.Error{
+			Code:    jsonrpc.ErrCodeMethodNotFound,
+			Message: fmt.Sprintf("method `%s` not found", req.Method),
+		}
+
+		return resp
+	}
+
+	result, rpcErr := handler(req.Params)
+	if rpcErr != nil {
+		resp.Error = rpcErr
+		return resp
+	}
+
+	resp.Result = result
+
+	return resp
+
+}
+
+// splitMethod - Splitting `namespace_method` on its first underscore
+func splitMethod(method string) (namespace string, name string, ok bool) {
+
+	idx := strings.Index(method, "_")
+	if idx <= 0 || idx == len(method)-1 {
+		return "", "", false
+	}
+
+	return method[:idx], method[idx+1:], true
+
+}
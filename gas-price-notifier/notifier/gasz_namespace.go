@@ -0,0 +1,114 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"gas-price-notifier/data"
+	"gas-price-notifier/jsonrpc"
+	"gas-price-notifier/pubsub"
+
+	"github.com/gorilla/websocket"
+)
+
+// gaszSubscribeFilter - Filter criteria accepted as the second element of
+// `gasz_subscribe`'s `params` array; which fields apply depends on the
+// subscription name given as the first element
+type gaszSubscribeFilter struct {
+	Token       string         `json:"token,omitempty"`
+	Criteria    *data.Criteria `json:"criteria,omitempty"`
+	Address     string         `json:"address,omitempty"`
+	Topics      []string       `json:"topics,omitempty"`
+	Channel     string         `json:"channel,omitempty"`
+	Replay      int64          `json:"replay,omitempty"`
+	MinInterval int64          `json:"minInterval,omitempty"`
+}
+
+// registerGaszNamespace - Registering the `gasz_subscribe` / `gasz_unsubscribe`
+// methods against `dispatcher`, closing over the state of one websocket
+// connection so every call operates on that client's own subscriptions
+func registerGaszNamespace(dispatcher *Dispatcher, ctx context.Context, conn *websocket.Conn, hub *pubsub.Hub, lock *sync.Mutex, subscriptions map[string]*data.PriceSubscription) {
+
+	dispatcher.Register("gasz", "subscribe", func(params []byte) (interface{}, *jsonrpc.Error) {
+
+		var args []json.RawMessage
+		if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 {
+			return nil, &jsonrpc.Error{Code: jsonrpc.ErrCodeInvalidParams, Message: "expected params `[name, filter]`"}
+		}
+
+		var name string
+		if err := json.Unmarshal(args[0], &name); err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.ErrCodeInvalidParams, Message: "unsupported subscription name"}
+		}
+
+		// `priceFeed` is kept as an alias for `newGasPrice`, the name this
+		// subscription type shipped under before go-ethereum-style naming
+		kind := name
+		if kind == "priceFeed" {
+			kind = data.KindNewGasPrice
+		}
+
+		switch kind {
+		case data.KindNewGasPrice, data.KindNewBlock, data.KindLogs:
+		default:
+			return nil, &jsonrpc.Error{Code: jsonrpc.ErrCodeInvalidParams, Message: "unsupported subscription name"}
+		}
+
+		var filter gaszSubscribeFilter
+		if len(args) > 1 {
+			if err := json.Unmarshal(args[1], &filter); err != nil {
+				return nil, &jsonrpc.Error{Code: jsonrpc.ErrCodeInvalidParams, Message: "bad filter"}
+			}
+		}
+
+		payload := &data.Payload{
+			Type:        "subscription",
+			Kind:        kind,
+			Token:       filter.Token,
+			Criteria:    filter.Criteria,
+			Channel:     filter.Channel,
+			Replay:      filter.Replay,
+			MinInterval: filter.MinInterval,
+			ID:          jsonrpc.NextSubscriptionID(),
+		}
+
+		if kind == data.KindLogs {
+			payload.LogsFilter = &data.LogsFilter{Address: filter.Address, Topics: filter.Topics}
+		}
+
+		if err := payload.Validate(); err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.ErrCodeInvalidParams, Message: err.Error()}
+		}
+
+		sub, err := data.NewPriceSubscription(ctx, conn, payload, hub, lock)
+		if err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.ErrCodeInternal, Message: err.Error()}
+		}
+
+		subscriptions[payload.ID] = sub
+
+		return payload.ID, nil
+
+	})
+
+	dispatcher.Register("gasz", "unsubscribe", func(params []byte) (interface{}, *jsonrpc.Error) {
+
+		var args []string
+		if err := json.Unmarshal(params, &args); err != nil || len(args) != 1 {
+			return nil, &jsonrpc.Error{Code: jsonrpc.ErrCodeInvalidParams, Message: "expected params `[subscriptionID]`"}
+		}
+
+		sub, ok := subscriptions[args[0]]
+		if !ok {
+			return false, nil
+		}
+
+		sub.Stop()
+		delete(subscriptions, args[0])
+
+		return true, nil
+
+	})
+
+}
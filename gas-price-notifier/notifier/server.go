@@ -1,9 +1,11 @@
 package notifier
 
 import (
+	"encoding/json"
 	"fmt"
 	"gas-price-notifier/config"
 	"gas-price-notifier/data"
+	"gas-price-notifier/jsonrpc"
 	"gas-price-notifier/pubsub"
 	"log"
 	"net/http"
@@ -17,8 +19,11 @@ import (
 // Start - Manages whole lifecycle of backend application
 func Start() {
 
-	redisClient := pubsub.Connect()
-	defer redisClient.Close()
+	// Single shared hub, fanning out the one upstream broker subscription
+	// to every connected client's `PriceSubscription`, instead of each
+	// client opening its own subscriber
+	hub, closeBroker := newHub()
+	defer closeBroker()
 
 	connCount := data.SafeActiveConnections{
 		Lock:        &sync.RWMutex{},
@@ -106,22 +111,74 @@ func Start() {
 			subscriptions := make(map[string]*data.PriceSubscription)
 			lock := sync.Mutex{}
 
+			// JSON-RPC 2.0 dispatcher for this connection, exposing the
+			// `gasz_subscribe` / `gasz_unsubscribe` methods alongside the
+			// legacy payload protocol below
+			dispatcher := NewDispatcher()
+			registerGaszNamespace(dispatcher, c.Request().Context(), conn, hub, &lock, subscriptions)
+
 			// Unsubscribing from all subscriptions, for this client
 			defer func() {
 				for _, v := range subscriptions {
-					v.Request.Type = "unsubscription"
+					v.Stop()
 				}
 			}()
 
 			// Handling client request and responding accordingly
 			for {
 
-				var payload data.Payload
+				_, raw, err := conn.ReadMessage()
+				if err != nil {
+					log.Printf("[!] Failed to read data from client : %s\n", err.Error())
+					break
+				}
+
+				// Probing for the JSON-RPC 2.0 envelope first, so both
+				// protocols can be served over the same `v1/subscribe` connection
+				var probe struct {
+					Method string `json:"method"`
+				}
+				_ = json.Unmarshal(raw, &probe)
+
+				if probe.Method != "" {
+
+					var req jsonrpc.Request
+					if err := json.Unmarshal(raw, &req); err != nil {
+
+						lock.Lock()
+						writeErr := conn.WriteJSON(&jsonrpc.Response{
+							JSONRPC: jsonrpc.Version,
+							Error:   &jsonrpc.Error{Code: jsonrpc.ErrCodeParse, Message: "failed to parse request"},
+						})
+						lock.Unlock()
+
+						if writeErr != nil {
+							log.Printf("[!] Failed to communicate with client : %s\n", writeErr.Error())
+							break
+						}
+
+						continue
+					}
+
+					resp := dispatcher.Dispatch(&req)
+
+					lock.Lock()
+					writeErr := conn.WriteJSON(resp)
+					lock.Unlock()
+
+					if writeErr != nil {
+						log.Printf("[!] Failed to communicate with client : %s\n", writeErr.Error())
+						break
+					}
 
-				// Reading JSON data from client
-				if err := conn.ReadJSON(&payload); err != nil {
+					continue
+
+				}
+
+				// Legacy `v1/subscribe` payload protocol, kept alongside JSON-RPC
+				var payload data.Payload
+				if err := json.Unmarshal(raw, &payload); err != nil {
 					log.Printf("[!] Failed to read data from client : %s\n", err.Error())
-					// In case, some error is faced, unlocking critical section here
 					break
 				}
 
@@ -183,7 +240,32 @@ func Start() {
 					// Creating subscription entry for this client in associative array
 					//
 					// To be used in future when `unsubscription` request to be received
-					subscriptions[payload.String()] = data.NewPriceSubscription(c.Request().Context(), conn, &payload, redisClient, &lock)
+					sub, err := data.NewPriceSubscription(c.Request().Context(), conn, &payload, hub, &lock)
+					if err != nil {
+
+						log.Printf("[!] Failed to subscribe : %s\n", err.Error())
+
+						resp := data.ClientResponse{
+							Code:    0,
+							Message: "Subscribe Failed",
+						}
+
+						// -- Critical section code, starts
+						lock.Lock()
+
+						if err := conn.WriteJSON(&resp); err != nil {
+							facedErrorInSwitchCase = true
+							log.Printf("[!] Failed to communicate with client : %s\n", err.Error())
+						}
+
+						lock.Unlock()
+						// -- Critical section code, ends
+
+						break
+
+					}
+
+					subscriptions[payload.String()] = sub
 
 				case "unsubscription":
 
@@ -213,7 +295,7 @@ func Start() {
 
 					// Cancelling subscription
 					if subs != nil {
-						subs.Request.Type = "unsubscription"
+						subs.Stop()
 					}
 
 					// Removing subscription entry from associative array
@@ -239,3 +321,29 @@ func Start() {
 	}
 
 }
+
+// newHub - Selecting the pub/sub backend via the `Backend` config key
+// (`redis`, the default, or `nats`) and wrapping it in a `pubsub.Hub`,
+// along with a function to cleanly shut the backend back down
+func newHub() (*pubsub.Hub, func()) {
+
+	switch config.Get("Backend") {
+
+	case "nats":
+
+		broker, err := pubsub.NewNATSBroker(config.Get("NATSUrl"))
+		if err != nil {
+			log.Fatalf("[!] Failed to connect to NATS : %s\n", err.Error())
+		}
+
+		return pubsub.NewHub(broker), broker.Close
+
+	default:
+
+		redisClient := pubsub.Connect()
+
+		return pubsub.NewHub(pubsub.NewRedisBroker(redisClient)), func() { redisClient.Close() }
+
+	}
+
+}
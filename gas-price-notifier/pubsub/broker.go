@@ -0,0 +1,42 @@
+package pubsub
+
+import (
+	"context"
+	"time"
+)
+
+// Message - Single pub/sub message, decoded from whichever backend
+// (Redis or NATS JetStream) actually delivered it
+type Message struct {
+	// Channel - Channel this message was actually published to
+	Channel string
+	// Data - Raw message payload, still to be JSON-decoded by the caller
+	Data []byte
+}
+
+// Broker - Pluggable pub/sub backend. `RedisBroker` and `NATSBroker`
+// both implement this so the rest of `pubsub`/ `notifier`/ `data` never
+// talk to go-redis or NATS directly, and a `Backend` config switch is
+// enough to swap one for the other
+type Broker interface {
+
+	// Publish - Publishing payload on channel
+	Publish(ctx context.Context, channel string, payload []byte) error
+
+	// Subscribe - Subscribing to channel (or, when pattern is true, a
+	// glob pattern matched against channels published to), returning a
+	// channel of decoded messages and a `CancelFunc` to tear the
+	// subscription back down
+	Subscribe(ctx context.Context, channel string, pattern bool) (<-chan Message, CancelFunc, error)
+}
+
+// Replayer - Optional capability a `Broker` may implement to serve
+// historical messages to a reconnecting client. Only `NATSBroker`
+// implements this, since plain Redis pub/sub keeps no history to replay
+type Replayer interface {
+
+	// Replay - Replaying every message published to channel in the last
+	// `since` window, invoking `deliver` once per historical message,
+	// before returning
+	Replay(ctx context.Context, channel string, since time.Duration, deliver func(Message)) error
+}
@@ -0,0 +1,177 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// CancelFunc - Unregisters a listener previously obtained from `Hub.Register`
+// or `Hub.RegisterPattern`, or tears down a subscription opened by
+// `Broker.Subscribe`
+type CancelFunc func()
+
+// fanout - Tracks the single broker subscription backing one channel (or
+// pattern) along with every listener currently registered against it
+type fanout struct {
+	messages  <-chan Message
+	teardown  CancelFunc
+	listeners map[chan Message]struct{}
+}
+
+// Hub - Multiplexes a single broker subscription per channel/ pattern
+// across every listener interested in it, so that N connected websocket
+// clients no longer each open an independent subscription against the
+// same channel
+//
+// The first `Register`/ `RegisterPattern` call for a key opens the
+// underlying broker subscription and starts a single reader goroutine;
+// every subsequent call for that same key is served from the same
+// goroutine. The last listener to unregister tears the subscription
+// down again
+//
+// Hub is agnostic to which `Broker` backs it - Redis or NATS JetStream -
+// so selecting between the two is just a matter of what's passed to `NewHub`
+type Hub struct {
+	broker Broker
+
+	mu       sync.Mutex
+	channels map[string]*fanout
+}
+
+// NewHub - Creating new pub/sub multiplexer, backed by given Broker
+func NewHub(broker Broker) *Hub {
+	return &Hub{
+		broker:   broker,
+		channels: make(map[string]*fanout),
+	}
+}
+
+// Register - Registering interest in `channel`, returning a buffered
+// channel on which decoded messages will be delivered and a
+// `CancelFunc` to be invoked once the listener is no longer interested
+//
+// Opens a single broker subscription per distinct channel, no matter how
+// many listeners register against it. Returns an error, with both other
+// return values nil, if that broker subscription fails
+func (h *Hub) Register(ctx context.Context, channel string) (<-chan Message, CancelFunc, error) {
+	return h.register(channel, false)
+}
+
+// RegisterPattern - Same as `Register`, but matches channel as a glob
+// pattern (e.g. `gas.*`) against every channel published to, rather than
+// requiring an exact channel name
+func (h *Hub) RegisterPattern(ctx context.Context, pattern string) (<-chan Message, CancelFunc, error) {
+	return h.register(pattern, true)
+}
+
+// register - Shared implementation behind `Register`/ `RegisterPattern`,
+// keying the fan-out set by `key` plus whether it's a pattern
+// subscription, so the same name can't collide across the two kinds
+func (h *Hub) register(key string, pattern bool) (<-chan Message, CancelFunc, error) {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	mapKey := registryKey(key, pattern)
+
+	fo, ok := h.channels[mapKey]
+	if !ok {
+
+		messages, teardown, err := h.broker.Subscribe(context.Background(), key, pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to subscribe to `%s` : %w", key, err)
+		}
+
+		fo = &fanout{
+			messages:  messages,
+			teardown:  teardown,
+			listeners: make(map[chan Message]struct{}),
+		}
+
+		h.channels[mapKey] = fo
+		go h.run(key, fo)
+	}
+
+	out := make(chan Message, 16)
+	fo.listeners[out] = struct{}{}
+
+	return out, func() { h.unregister(mapKey, out) }, nil
+
+}
+
+// run - Single reader goroutine for `key`, fanning every received
+// message out to all listeners currently registered against it
+//
+// Exactly one of these runs per distinct channel/ pattern, regardless of
+// how many websocket clients are subscribed to it
+func (h *Hub) run(key string, fo *fanout) {
+
+	for msg := range fo.messages {
+
+		h.mu.Lock()
+		for listener := range fo.listeners {
+			select {
+			case listener <- msg:
+			default:
+				log.Printf("[!] Dropped pubsub message for slow listener on `%s`\n", key)
+			}
+		}
+		h.mu.Unlock()
+
+	}
+
+}
+
+// unregister - Removing listener from `key`'s fan-out set, tearing down
+// the underlying broker subscription once nobody's left listening
+func (h *Hub) unregister(mapKey string, out chan Message) {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fo, ok := h.channels[mapKey]
+	if !ok {
+		return
+	}
+
+	delete(fo.listeners, out)
+	close(out)
+
+	if len(fo.listeners) == 0 {
+		fo.teardown()
+		delete(h.channels, mapKey)
+	}
+
+}
+
+// Replay - Replaying the last `since` worth of history for `channel`
+// from the broker, delivering each historical message to `deliver`
+// before returning
+//
+// Only brokers implementing `Replayer` (currently `NATSBroker`) support
+// this; the Redis backend returns an error since plain Redis pub/sub
+// keeps no history
+func (h *Hub) Replay(ctx context.Context, channel string, since time.Duration, deliver func(Message)) error {
+
+	replayer, ok := h.broker.(Replayer)
+	if !ok {
+		return errors.New("pub/sub backend doesn't support replay")
+	}
+
+	return replayer.Replay(ctx, channel, since, deliver)
+
+}
+
+// registryKey - Namespacing `key` by subscription kind, so a channel and
+// a pattern that happen to share the same literal name track separate
+// fan-out sets
+func registryKey(key string, pattern bool) string {
+	if pattern {
+		return "p:" + key
+	}
+	return "c:" + key
+}
@@ -0,0 +1,222 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// streamName - Single catch-all JetStream stream backing every gasz
+// subject; created lazily by `NewNATSBroker` so `Publish`/ `Subscribe`
+// don't depend on a stream having been provisioned out-of-band
+const streamName = "GASZ_EVENTS"
+
+// NATSBroker - Broker implementation backed by NATS JetStream, giving
+// gasz durable, at-least-once delivery and the ability to replay recent
+// history to a reconnecting client - something plain Redis pub/sub
+// can't offer
+type NATSBroker struct {
+	conn *nats.Conn
+	js   nats.JetStreamContext
+}
+
+// NewNATSBroker - Connecting to NATS at url, obtaining a JetStream
+// context and ensuring the stream gasz publishes to exists
+func NewNATSBroker(url string) (*NATSBroker, error) {
+
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS : %w", err)
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to get JetStream context : %w", err)
+	}
+
+	broker := &NATSBroker{conn: conn, js: js}
+
+	if err := broker.ensureStream(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return broker, nil
+
+}
+
+// ensureStream - Creating the stream backing every gasz subject, if it
+// doesn't already exist; `js.Publish`/ `js.Subscribe` otherwise fail
+// outright against a fresh NATS server with no stream provisioned
+func (b *NATSBroker) ensureStream() error {
+
+	if _, err := b.js.StreamInfo(streamName); err == nil {
+		return nil
+	}
+
+	_, err := b.js.AddStream(&nats.StreamConfig{
+		Name:     streamName,
+		Subjects: []string{">"},
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create stream `%s` : %w", streamName, err)
+	}
+
+	return nil
+
+}
+
+// Close - Closing underlying NATS connection
+func (b *NATSBroker) Close() {
+	b.conn.Close()
+}
+
+// Publish - Publishing payload on channel, as a JetStream subject
+func (b *NATSBroker) Publish(ctx context.Context, channel string, payload []byte) error {
+	_, err := b.js.Publish(channel, payload)
+	return err
+}
+
+// ensureConsumer - Provisioning channel's durable consumer out-of-band,
+// if it doesn't already exist
+//
+// This matters because `js.Subscribe` with `nats.Durable(...)` alone
+// still has the client library create (and therefore own) the consumer,
+// which nats.go always marks delete-on-unsubscribe regardless of the
+// durable name - so `sub.Unsubscribe()` would delete it the moment the
+// Hub's last listener for this channel goes away, and the next
+// subscriber would start over from `nats.DeliverNew()`, losing exactly
+// the history durability is supposed to preserve. Creating the consumer
+// here and binding to it in `Subscribe` keeps it alive across listeners
+func (b *NATSBroker) ensureConsumer(channel string) error {
+
+	name := durableName(channel)
+
+	if _, err := b.js.ConsumerInfo(streamName, name); err == nil {
+		return nil
+	}
+
+	_, err := b.js.AddConsumer(streamName, &nats.ConsumerConfig{
+		Durable:        name,
+		FilterSubject:  channel,
+		DeliverSubject: nats.NewInbox(),
+		DeliverPolicy:  nats.DeliverNewPolicy,
+		AckPolicy:      nats.AckExplicitPolicy,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create consumer `%s` : %w", name, err)
+	}
+
+	return nil
+
+}
+
+// Subscribe - Subscribing to channel via a durable JetStream push
+// consumer, delivering only messages published after the consumer is
+// first created and surviving a reconnect without losing consumer state
+//
+// `pattern` needs no special handling here - a Redis-style glob such as
+// `gas.*` is already valid NATS subject wildcard syntax
+func (b *NATSBroker) Subscribe(ctx context.Context, channel string, pattern bool) (<-chan Message, CancelFunc, error) {
+
+	out := make(chan Message, 16)
+
+	if err := b.ensureConsumer(channel); err != nil {
+		close(out)
+		return nil, nil, err
+	}
+
+	// Guarding every send against a concurrent `close(out)` from
+	// `teardown` - the NATS client invokes this callback on its own
+	// delivery goroutine, so `Unsubscribe` returning is no guarantee
+	// that a send below isn't still in flight
+	var mu sync.Mutex
+	closed := false
+
+	// Binding to the consumer provisioned by `ensureConsumer` instead of
+	// `nats.Durable(...)` alone, so this subscription doesn't own it and
+	// `sub.Unsubscribe()` below only detaches the client-side handle -
+	// the consumer itself survives every listener going away
+	sub, err := b.js.Subscribe(channel, func(msg *nats.Msg) {
+
+		mu.Lock()
+		if !closed {
+			out <- Message{Channel: msg.Subject, Data: msg.Data}
+		}
+		mu.Unlock()
+
+		msg.Ack()
+
+	}, nats.Bind(streamName, durableName(channel)))
+
+	if err != nil {
+		close(out)
+		return nil, nil, fmt.Errorf("failed to subscribe to `%s` : %w", channel, err)
+	}
+
+	teardown := func() {
+
+		sub.Unsubscribe()
+
+		mu.Lock()
+		closed = true
+		close(out)
+		mu.Unlock()
+
+	}
+
+	return out, teardown, nil
+
+}
+
+// Replay - Replaying every message JetStream has retained for channel
+// since `since`, delivering each one to `deliver` before returning. This
+// is what serves a reconnecting client's `params.replay` request
+func (b *NATSBroker) Replay(ctx context.Context, channel string, since time.Duration, deliver func(Message)) error {
+
+	done := make(chan struct{})
+
+	sub, err := b.js.Subscribe(channel, func(msg *nats.Msg) {
+
+		deliver(Message{Channel: msg.Subject, Data: msg.Data})
+		msg.Ack()
+
+		meta, err := msg.Metadata()
+		if err == nil && meta.NumPending == 0 {
+			close(done)
+		}
+
+	}, nats.StartTime(time.Now().Add(-since)))
+
+	if err != nil {
+		return fmt.Errorf("failed to replay `%s` : %w", channel, err)
+	}
+
+	defer sub.Unsubscribe()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		// No further historical messages arrived in time; move on
+		// rather than blocking the subscribing client indefinitely
+	}
+
+	return nil
+
+}
+
+// durableName - Deriving a valid JetStream durable consumer name from
+// channel, so a reconnecting subscriber resumes the same consumer
+// instead of losing its position
+func durableName(channel string) string {
+	replacer := strings.NewReplacer(".", "_", "*", "star", ">", "gt", "?", "q")
+	return "gasz_" + replacer.Replace(channel)
+}
@@ -0,0 +1,70 @@
+package pubsub
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisBroker - Broker implementation backed by Redis pub/sub, the
+// original (and still default) transport gasz shipped with
+type RedisBroker struct {
+	client *redis.Client
+}
+
+// NewRedisBroker - Wrapping redisClient as a Broker
+func NewRedisBroker(redisClient *redis.Client) *RedisBroker {
+	return &RedisBroker{client: redisClient}
+}
+
+// Publish - Publishing payload on channel
+func (b *RedisBroker) Publish(ctx context.Context, channel string, payload []byte) error {
+	return b.client.Publish(ctx, channel, payload).Err()
+}
+
+// Subscribe - Subscribing to channel via `SUBSCRIBE`, or `PSUBSCRIBE`
+// when pattern is true, and forwarding every received message onto the
+// returned channel until the `CancelFunc` is invoked
+func (b *RedisBroker) Subscribe(ctx context.Context, channel string, pattern bool) (<-chan Message, CancelFunc, error) {
+
+	subCtx, cancel := context.WithCancel(context.Background())
+
+	var ps *redis.PubSub
+	if pattern {
+		ps = b.client.PSubscribe(subCtx, channel)
+	} else {
+		ps = b.client.Subscribe(subCtx, channel)
+	}
+
+	out := make(chan Message, 16)
+
+	go func() {
+		defer close(out)
+
+		for {
+			msg, err := ps.ReceiveMessage(subCtx)
+			if err != nil {
+				// Context got cancelled because the listener tore this
+				// subscription down, or the connection to Redis got closed
+				return
+			}
+
+			out <- Message{Channel: msg.Channel, Data: []byte(msg.Payload)}
+		}
+	}()
+
+	teardown := func() {
+		cancel()
+
+		if pattern {
+			ps.PUnsubscribe(context.Background(), channel)
+		} else {
+			ps.Unsubscribe(context.Background(), channel)
+		}
+
+		ps.Close()
+	}
+
+	return out, teardown, nil
+
+}